@@ -0,0 +1,56 @@
+package tarutil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte{0x42, 0x5a, 0x68}
+	xzMagic    = []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ExtractAllAuto is like ExtractAll, except it first peeks at r to
+// detect whether it is compressed and, if so, transparently
+// decompresses it. gzip and bzip2 are decoded directly; xz and
+// zstd are recognized by their magic numbers but, since decoding
+// them needs a decoder outside the standard library, are reported
+// as an error rather than silently extracted as raw tar. A stream
+// matching none of these signatures is assumed to be an
+// uncompressed tar.
+func ExtractAllAuto(r io.Reader, root string, flag int) error {
+	br := bufio.NewReader(r)
+	switch {
+	case hasPrefix(br, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		return ExtractAll(gr, root, flag)
+	case hasPrefix(br, bzip2Magic):
+		return ExtractAll(bzip2.NewReader(br), root, flag)
+	case hasPrefix(br, xzMagic):
+		return fmt.Errorf("tarutil: xz-compressed stream: decoding xz requires a decoder not in the standard library")
+	case hasPrefix(br, zstdMagic):
+		return fmt.Errorf("tarutil: zstd-compressed stream: decoding zstd requires a decoder not in the standard library")
+	default:
+		return ExtractAll(br, root, flag)
+	}
+}
+
+// hasPrefix reports whether the next bytes br will read are magic,
+// without consuming them.
+func hasPrefix(br *bufio.Reader, magic []byte) bool {
+	peek, err := br.Peek(len(magic))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(peek, magic)
+}
@@ -0,0 +1,67 @@
+package tarutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractAllAutoGzip(t *testing.T) {
+	raw := writeTestTar(t, map[string]string{"f": "hello"})
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	if _, err := gw.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	if err := ExtractAllAuto(&gz, root, Chmod); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestExtractAllAutoUncompressed(t *testing.T) {
+	raw := writeTestTar(t, map[string]string{"f": "plain"})
+
+	root := t.TempDir()
+	if err := ExtractAllAuto(bytes.NewReader(raw), root, Chmod); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "plain" {
+		t.Fatalf("got %q, want %q", got, "plain")
+	}
+}
+
+func TestExtractAllAutoXZUnsupported(t *testing.T) {
+	stream := append(append([]byte{}, xzMagic...), "not a real xz stream"...)
+	err := ExtractAllAuto(bytes.NewReader(stream), t.TempDir(), 0)
+	if err == nil || !strings.Contains(err.Error(), "xz") {
+		t.Fatalf("ExtractAllAuto(xz magic) error = %v, want an xz-unsupported error", err)
+	}
+}
+
+func TestExtractAllAutoZstdUnsupported(t *testing.T) {
+	stream := append(append([]byte{}, zstdMagic...), "not a real zstd stream"...)
+	err := ExtractAllAuto(bytes.NewReader(stream), t.TempDir(), 0)
+	if err == nil || !strings.Contains(err.Error(), "zstd") {
+		t.Fatalf("ExtractAllAuto(zstd magic) error = %v, want a zstd-unsupported error", err)
+	}
+}
@@ -0,0 +1,35 @@
+// +build linux
+
+package tarutil
+
+import (
+	"archive/tar"
+	"syscall"
+)
+
+// mkdev packs major and minor device numbers into the dev_t
+// encoding used by the Linux kernel (see makedev(3)). Other Unix
+// kernels pack dev_t differently, which is why this file is
+// built only on linux; see devices_other.go.
+func mkdev(major, minor int64) int {
+	dev := (uint64(major) & 0xfff) << 8
+	dev |= uint64(minor) & 0xff
+	dev |= (uint64(major) &^ 0xfff) << 32
+	dev |= (uint64(minor) &^ 0xff) << 12
+	return int(dev)
+}
+
+// mknod creates the device or fifo node described by hdr at path,
+// used when the Devices flag is set.
+func mknod(path string, hdr *tar.Header) error {
+	mode := uint32(hdr.Mode & 07777)
+	switch hdr.Typeflag {
+	case tar.TypeChar:
+		mode |= syscall.S_IFCHR
+	case tar.TypeBlock:
+		mode |= syscall.S_IFBLK
+	case tar.TypeFifo:
+		mode |= syscall.S_IFIFO
+	}
+	return syscall.Mknod(path, mode, mkdev(hdr.Devmajor, hdr.Devminor))
+}
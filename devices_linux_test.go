@@ -0,0 +1,21 @@
+// +build linux
+
+package tarutil
+
+import "testing"
+
+func TestMkdev(t *testing.T) {
+	cases := []struct {
+		major, minor int64
+		want         int
+	}{
+		{1, 5, 0x105},   // /dev/zero-style low major/minor
+		{8, 1, 0x801},   // /dev/sda1-style low major/minor
+		{0, 0, 0},
+	}
+	for _, c := range cases {
+		if got := mkdev(c.major, c.minor); got != c.want {
+			t.Errorf("mkdev(%d, %d) = %#x, want %#x", c.major, c.minor, got, c.want)
+		}
+	}
+}
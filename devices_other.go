@@ -0,0 +1,17 @@
+// +build !linux
+
+package tarutil
+
+import (
+	"archive/tar"
+	"fmt"
+	"runtime"
+)
+
+// mknod returns an error naming the platform and entry on
+// platforms we don't have a verified dev_t encoding for; the error
+// is a plain error value, not a distinct type. Device nodes are genuinely
+// Linux-only in this package for now; see devices_linux.go.
+func mknod(path string, hdr *tar.Header) error {
+	return fmt.Errorf("tarutil: device nodes are not supported on %s: %s", runtime.GOOS, hdr.Name)
+}
@@ -0,0 +1,79 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"io"
+)
+
+// FilterFunc is called once per tar entry before it is written to
+// disk. It may return a modified header to rename, remap, or
+// rewrite the entry; a false second result skips the entry
+// entirely; a non-nil error aborts the extraction, as for any
+// other error from ExtractAll.
+type FilterFunc func(*tar.Header) (*tar.Header, bool, error)
+
+// Extractor is a configurable alternative to ExtractAll. Where
+// ExtractAll is a single call for the common case, Extractor lets
+// callers inspect and rewrite each entry via Filter before it is
+// extracted, for uses like "extract only paths matching a glob",
+// "strip N leading path components" (as with tar's
+// --strip-components), or remapping uids/gids for rootless
+// extraction.
+type Extractor struct {
+	Root   string
+	Flag   int
+	Filter FilterFunc
+}
+
+// Option configures an Extractor returned by NewExtractor.
+type Option func(*Extractor)
+
+// WithFilter sets the FilterFunc run on every entry.
+func WithFilter(f FilterFunc) Option {
+	return func(e *Extractor) { e.Filter = f }
+}
+
+// NewExtractor returns an Extractor rooted at root with the given
+// flag (as accepted by ExtractAll), configured by opts.
+func NewExtractor(root string, flag int, opts ...Option) *Extractor {
+	e := &Extractor{Root: root, Flag: flag}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// ExtractAll reads tar entries from r until EOF, running each
+// through e.Filter (if set) before extracting it, with the same
+// error-accumulating behavior as the package-level ExtractAll.
+func (e *Extractor) ExtractAll(r io.Reader) error {
+	var err error
+	tr := tar.NewReader(r)
+	for {
+		hdr, err1 := tr.Next()
+		if err == nil && err1 != io.EOF {
+			err = err1
+		}
+		if err1 != nil {
+			break
+		}
+		if e.Filter != nil {
+			var ok bool
+			hdr, ok, err1 = e.Filter(hdr)
+			if err1 != nil {
+				if err == nil {
+					err = err1
+				}
+				break
+			}
+			if !ok {
+				continue
+			}
+		}
+		err1 = extractOne(hdr, tr, e.Root, e.Flag)
+		if err == nil {
+			err = err1
+		}
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractAllMatchesFilterlessExtractor(t *testing.T) {
+	data := writeTestTar(t, map[string]string{"a": "1", "b": "2"})
+
+	root1 := t.TempDir()
+	if err := ExtractAll(bytes.NewReader(data), root1, Chmod); err != nil {
+		t.Fatal(err)
+	}
+	root2 := t.TempDir()
+	e := NewExtractor(root2, Chmod)
+	if err := e.ExtractAll(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		got1, err := os.ReadFile(filepath.Join(root1, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got2, err := os.ReadFile(filepath.Join(root2, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got1) != string(got2) {
+			t.Fatalf("ExtractAll and Extractor.ExtractAll disagree on %q: %q vs %q", name, got1, got2)
+		}
+	}
+}
+
+// dirTestTar builds a tar stream with explicit directory entries
+// for each leading path component of file, the way a real archive
+// (and this package's own CreateAll) would produce one, followed
+// by a regular-file entry for file.
+func dirTestTar(t *testing.T, file, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	dir := filepath.Dir(file)
+	var prefix string
+	for _, part := range strings.Split(dir, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		if prefix != "" {
+			prefix += "/"
+		}
+		prefix += part
+		if err := tw.WriteHeader(&tar.Header{Name: prefix + "/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hdr := &tar.Header{Name: file, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}
+	if err := tw.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractorStripComponents(t *testing.T) {
+	data := dirTestTar(t, "pkg/sub/file.txt", "hi")
+
+	root := t.TempDir()
+	e := NewExtractor(root, Chmod, WithFilter(StripComponents(1)))
+	if err := e.ExtractAll(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "pkg")); !os.IsNotExist(err) {
+		t.Fatalf("expected leading component stripped, got err=%v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "sub", "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hi" {
+		t.Fatalf("got %q, want %q", got, "hi")
+	}
+}
+
+func TestExtractorRemapIDs(t *testing.T) {
+	data := writeTestTar(t, map[string]string{"f": "x"})
+
+	var seen []int
+	filter := RemapIDs(func(uid int) int {
+		seen = append(seen, uid)
+		return uid + 1000
+	}, nil)
+
+	root := t.TempDir()
+	e := NewExtractor(root, 0, WithFilter(filter))
+	if err := e.ExtractAll(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("RemapIDs filter ran %d times, want 1", len(seen))
+	}
+}
@@ -0,0 +1,36 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"strings"
+)
+
+// StripComponents returns a FilterFunc that removes the first n
+// leading path components from each entry's name, as with tar's
+// --strip-components. Entries with n or fewer components are
+// skipped.
+func StripComponents(n int) FilterFunc {
+	return func(hdr *tar.Header) (*tar.Header, bool, error) {
+		parts := strings.Split(strings.TrimRight(hdr.Name, "/"), "/")
+		if len(parts) <= n {
+			return nil, false, nil
+		}
+		hdr.Name = strings.Join(parts[n:], "/")
+		return hdr, true, nil
+	}
+}
+
+// RemapIDs returns a FilterFunc that rewrites each entry's uid and
+// gid by calling uid and gid, for example to remap container uids
+// into a host user namespace before extraction.
+func RemapIDs(uid, gid func(int) int) FilterFunc {
+	return func(hdr *tar.Header) (*tar.Header, bool, error) {
+		if uid != nil {
+			hdr.Uid = uid(hdr.Uid)
+		}
+		if gid != nil {
+			hdr.Gid = gid(hdr.Gid)
+		}
+		return hdr, true, nil
+	}
+}
@@ -0,0 +1,12 @@
+// +build windows
+
+package tarutil
+
+import "os"
+
+// inodeKey always reports false on platforms without a stable
+// inode number in os.FileInfo, so CreateAll never attempts
+// hardlink de-duplication there.
+func inodeKey(info os.FileInfo) (string, bool) {
+	return "", false
+}
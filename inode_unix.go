@@ -0,0 +1,20 @@
+// +build !windows
+
+package tarutil
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// inodeKey returns a string uniquely identifying the device and
+// inode backing info, for hardlink de-duplication in CreateAll.
+// It reports false if the underlying stat_t is unavailable.
+func inodeKey(info os.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", st.Dev, st.Ino), true
+}
@@ -0,0 +1,60 @@
+package tarutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBreakout is returned (wrapped, via errors.Is) by ExtractAll
+// when an archive entry would resolve to a path outside root, for
+// example via a symlink planted earlier in the same archive. It
+// lets callers distinguish a hostile archive from an ordinary I/O
+// error. This check is on by default; see InsecurePaths.
+var ErrBreakout = errors.New("tarutil: entry escapes root")
+
+// checkPath reports an error if path does not resolve to a
+// location inside root. It resolves symlinks up to the nearest
+// existing ancestor directory of path, so that an entry can't
+// escape root by walking through a symlink planted by an earlier
+// entry in the same archive; components below that ancestor can't
+// themselves be symlinks, since they don't exist on disk yet, so
+// they're appended to the resolved ancestor verbatim. This also
+// catches a plain ../-laden name with no symlink involved at all,
+// even when none of its intermediate directories exist yet (so a
+// later MkdirAll can't create the whole missing chain outside
+// root in one call).
+func checkPath(root, path string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		// root itself doesn't exist yet (first entry of the
+		// archive): fall back to a plain textual comparison.
+		realRoot = filepath.Clean(root)
+	}
+
+	dir := filepath.Dir(path)
+	suffix := filepath.Base(path)
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return checkWithin(realRoot, filepath.Join(resolved, suffix))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// reached the filesystem root without finding an
+			// existing ancestor to resolve.
+			return checkWithin(realRoot, filepath.Clean(path))
+		}
+		suffix = filepath.Join(filepath.Base(dir), suffix)
+		dir = parent
+	}
+}
+
+// checkWithin reports an error if path is not root or inside it.
+func checkWithin(root, path string) error {
+	if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return fmt.Errorf("tarutil: %q: %w", path, ErrBreakout)
+	}
+	return nil
+}
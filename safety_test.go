@@ -0,0 +1,110 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// escapeArchive builds a tar stream containing a symlink entry
+// "escape" that, once joined under an extraction root the way
+// extractOne joins hdr.Linkname, resolves to outside: a relative,
+// ../-laden Linkname computed from root's point of view, since an
+// absolute Linkname is (like hdr.Name) joined textually under
+// root rather than treated as an absolute path.
+func escapeArchive(t *testing.T, root, outside string) []byte {
+	t.Helper()
+	rel, err := filepath.Rel(root, outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []*tar.Header{
+		{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: rel, Mode: 0777},
+		{Name: "escape/pwned", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+	}
+	for i, hdr := range entries {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			if _, err := tw.Write([]byte("evil")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestExtractAllRejectsSymlinkEscape verifies that, with the
+// default flag (0, i.e. InsecurePaths not set), ExtractAll refuses
+// to write through a symlink planted earlier in the same archive
+// that points outside root.
+func TestExtractAllRejectsSymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	data := escapeArchive(t, root, outside)
+
+	err := ExtractAll(bytes.NewReader(data), root, Symlink)
+	if !errors.Is(err, ErrBreakout) {
+		t.Fatalf("ExtractAll error = %v, want ErrBreakout", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(outside, "pwned")); !os.IsNotExist(statErr) {
+		t.Fatalf("file was written outside root: %v", statErr)
+	}
+}
+
+// TestExtractAllInsecurePaths verifies that InsecurePaths opts
+// back out of the check above.
+func TestExtractAllInsecurePaths(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	data := escapeArchive(t, root, outside)
+
+	if err := ExtractAll(bytes.NewReader(data), root, Symlink|InsecurePaths); err != nil {
+		t.Fatalf("ExtractAll with InsecurePaths: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwned")); err != nil {
+		t.Fatalf("expected file outside root with InsecurePaths set: %v", err)
+	}
+}
+
+// TestExtractAllRejectsDirChainEscape verifies that a directory
+// entry escaping root via a plain ../-laden name is rejected even
+// when none of its intermediate path components exist yet, so
+// MkdirAll can't be used to create the whole missing chain
+// outside root in one call.
+func TestExtractAllRejectsDirChainEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	rel, err := filepath.Rel(root, filepath.Join(outside, "neverexisted", "evil", "payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: rel, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	extractErr := ExtractAll(bytes.NewReader(buf.Bytes()), root, 0)
+	if !errors.Is(extractErr, ErrBreakout) {
+		t.Fatalf("ExtractAll error = %v, want ErrBreakout", extractErr)
+	}
+	if _, statErr := os.Stat(filepath.Join(outside, "neverexisted")); !os.IsNotExist(statErr) {
+		t.Fatalf("directory chain was created outside root: %v", statErr)
+	}
+}
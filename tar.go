@@ -0,0 +1,128 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// unixEpoch is written into headers for the mtime/atime/ctime
+// fields when Chtimes is unset, so archives are reproducible
+// regardless of when CreateAll ran.
+var unixEpoch = time.Unix(0, 0)
+
+// CreateAll walks the file tree rooted at root and writes a tar
+// stream of its contents to w. It is the inverse of ExtractAll:
+// a tar produced by CreateAll and then passed to ExtractAll with
+// the same flag reproduces the tree (subject to the usual
+// caveats about permissions and ownership requiring privilege).
+//
+// Behavior changes according to flag, the same bitwise-or of
+// constants accepted by ExtractAll:
+//
+//   Link     emit a hardlink entry for files already seen under
+//            a different name instead of duplicating their data
+//   Symlink  emit symlink entries for symbolic links; if unset,
+//            symbolic links are skipped rather than followed
+//   Chown    record uid and gid in the header
+//   Chmod    record the file mode in the header
+//   Chtimes  record atime and mtime in the header
+//
+// If Chmod is unset, headers are written with mode 0666 for
+// regular files and 0777 for directories.
+func CreateAll(w io.Writer, root string, flag int) error {
+	tw := tar.NewWriter(w)
+	seen := make(map[string]string) // content key -> first path seen
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return addOne(tw, root, path, info, flag, seen)
+	})
+	if err != nil {
+		tw.Close()
+		return err
+	}
+	return tw.Close()
+}
+
+func addOne(tw *tar.Writer, root, path string, info os.FileInfo, flag int, seen map[string]string) error {
+	name, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return nil
+	}
+	name = filepath.ToSlash(name)
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if flag&Symlink == 0 {
+			return nil // mirrors ExtractAll: unset flag means skip, not a broken entry
+		}
+		link, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if flag&Chmod == 0 {
+		if info.IsDir() {
+			hdr.Mode = 0777
+		} else {
+			hdr.Mode = 0666
+		}
+	}
+	if flag&Chown == 0 {
+		hdr.Uid, hdr.Gid = 0, 0
+		hdr.Uname, hdr.Gname = "", ""
+	}
+	if flag&Chtimes == 0 {
+		hdr.ModTime = unixEpoch
+		hdr.AccessTime = unixEpoch
+		hdr.ChangeTime = unixEpoch
+	} else {
+		// tar.Writer drops AccessTime unless the format is PAX or GNU.
+		hdr.Format = tar.FormatPAX
+	}
+
+	if flag&Link != 0 && link == "" && info.Mode().IsRegular() {
+		if key, ok := inodeKey(info); ok {
+			if first, dup := seen[key]; dup {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = first
+				hdr.Size = 0
+				return tw.WriteHeader(hdr)
+			}
+			seen[key] = name
+		}
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if info.Mode().IsRegular() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
@@ -0,0 +1,112 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateAllSkipsSymlinksByDefault(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "real"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := CreateAll(&buf, root, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "link" {
+			t.Fatalf("got entry for symlink with Symlink flag unset: %+v", hdr)
+		}
+	}
+}
+
+func TestCreateAllSymlinkFlag(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "real"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := CreateAll(&buf, root, Symlink); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(&buf)
+	var found bool
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "link" {
+			found = true
+			if hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "real" {
+				t.Fatalf("got %+v, want symlink to \"real\"", hdr)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("no entry for symlink with Symlink flag set")
+	}
+}
+
+func TestCreateAllDedupsHardlinks(t *testing.T) {
+	root := t.TempDir()
+	first := filepath.Join(root, "first")
+	if err := os.WriteFile(first, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(first, filepath.Join(root, "second")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := CreateAll(&buf, root, Link); err != nil {
+		t.Fatal(err)
+	}
+
+	var regCount, linkCount int
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			regCount++
+		case tar.TypeLink:
+			linkCount++
+		}
+	}
+	if regCount != 1 || linkCount != 1 {
+		t.Fatalf("got %d TypeReg and %d TypeLink entries, want 1 and 1", regCount, linkCount)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -16,8 +17,22 @@ const (
 	Chown
 	Chmod
 	Chtimes
+	Whiteout
+	Devices
+	Xattrs
+	InsecurePaths
 )
 
+// whiteoutPrefix marks a file as a deleted marker for the file of
+// the same name without the prefix, per the OCI image-spec and
+// AUFS whiteout conventions.
+const whiteoutPrefix = ".wh."
+
+// whiteoutOpaqueDir, found alone in a directory, marks that
+// directory as opaque: any content from a lower layer should be
+// hidden rather than merged with it.
+const whiteoutOpaqueDir = whiteoutPrefix + whiteoutPrefix + ".opq"
+
 // ExtractAll reads tar entries from r until EOF and creates
 // filesystem entries rooted in root. It extracts everything it
 // can but returns the first error it encounters. It cleans paths
@@ -32,6 +47,23 @@ const (
 //   Chown    attempt to set file owner and group
 //   Chmod    attempt to set file mode
 //   Chtimes  attempt to set atime and mtime
+//   Whiteout apply OCI/AUFS whiteout entries instead of extracting
+//            them literally
+//   Devices  create character, block and fifo device nodes (Linux
+//            only; elsewhere such entries are an error)
+//   Xattrs   restore extended attributes, including POSIX ACLs,
+//            recorded as PAX records (Linux only; ignored elsewhere)
+//   InsecurePaths
+//            skip the path-breakout check described below
+//
+// In addition to the path cleaning always applied to entry names,
+// ExtractAll resolves symlinks in each entry's parent directory
+// and rejects entries (with ErrBreakout) whose resolved path, or
+// for hardlinks whose resolved link target, falls outside root —
+// the class of CVEs that has repeatedly hit Docker/containerd
+// archive code. This costs extra symlink resolution (several
+// stats) per entry; set InsecurePaths to skip it for trusted
+// archives, in which case entries may be created outside root.
 //
 // If Chmod is unset, files are created with mode 0666 (subject to
 // umask) and directories are created with mode 0777 (subject to
@@ -40,28 +72,40 @@ const (
 // Flag Chown uses only uid and gid, ignoring user name and group
 // name.
 func ExtractAll(r io.Reader, root string, flag int) error {
-	var err error
-	tr := tar.NewReader(r)
-	for {
-		hdr, err1 := tr.Next()
-		if err == nil && err1 != io.EOF {
-			err = err1
-		}
-		if err1 != nil {
-			break
-		}
-		err1 = extractOne(hdr, tr, root, flag)
-		if err == nil {
-			err = err1
-		}
-	}
-	return err
+	return (&Extractor{Root: root, Flag: flag}).ExtractAll(r)
 }
 
 func extractOne(hdr *tar.Header, r io.Reader, root string, flag int) error {
 	// clean before joining to remove all .. elements
 	path := filepath.Join(root, filepath.Clean(hdr.Name))
 	targ := filepath.Join(root, filepath.Clean(hdr.Linkname))
+
+	if flag&InsecurePaths == 0 {
+		if err := checkPath(root, path); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			if err := checkPath(root, targ); err != nil {
+				return err
+			}
+		}
+	}
+
+	if flag&Whiteout != 0 {
+		dir, base := filepath.Split(filepath.Clean(hdr.Name))
+		if base == whiteoutOpaqueDir {
+			return opaqueDir(filepath.Join(root, dir))
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			target := filepath.Join(root, dir, base[len(whiteoutPrefix):])
+			err := os.RemoveAll(target)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+	}
+
 	switch hdr.Typeflag {
 	case tar.TypeReg, tar.TypeRegA:
 		f, err := os.Create(path)
@@ -93,7 +137,12 @@ func extractOne(hdr *tar.Header, r io.Reader, root string, flag int) error {
 	case tar.TypeCont, tar.TypeXHeader, tar.TypeXGlobalHeader:
 		return nil
 	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
-		return fmt.Errorf("tarutil: unsupported type %q: %s", hdr.Typeflag, hdr.Name)
+		if flag&Devices == 0 {
+			return fmt.Errorf("tarutil: unsupported type %q: %s", hdr.Typeflag, hdr.Name)
+		}
+		if err := mknod(path, hdr); err != nil {
+			return err
+		}
 	}
 	if flag&Chtimes != 0 {
 		if err := os.Chtimes(path, hdr.AccessTime, hdr.ModTime); err != nil {
@@ -112,5 +161,10 @@ func extractOne(hdr *tar.Header, r io.Reader, root string, flag int) error {
 			return err
 		}
 	}
+	if flag&Xattrs != 0 {
+		if err := restoreXattrs(path, hdr); err != nil {
+			return err
+		}
+	}
 	return nil
 }
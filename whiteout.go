@@ -0,0 +1,124 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// opaqueDir removes the contents of dir without removing dir
+// itself, implementing the ".wh..wh..opq" marker: the directory
+// is kept, but nothing beneath it carries over from a lower
+// layer.
+func opaqueDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffTarStream walks the directory trees rooted at a and b and
+// returns a tar stream, in the style of an OCI image layer, of
+// the changes needed to turn a into b: added or modified files
+// are emitted normally, and files present in a but missing from b
+// are emitted as whiteout markers (see the Whiteout flag).
+//
+// The caller must Close the returned reader; doing so releases
+// the goroutine performing the walk and diff.
+func DiffTarStream(ctx context.Context, a, b string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeDiff(ctx, pw, a, b))
+	}()
+	return pr
+}
+
+func writeDiff(ctx context.Context, w io.Writer, a, b string) error {
+	tw := tar.NewWriter(w)
+	seen := make(map[string]string)
+
+	err := filepath.Walk(b, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		rel, err := filepath.Rel(b, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if same, err := filesEqual(filepath.Join(a, rel), path, info); err == nil && same {
+			return nil
+		}
+		return addOne(tw, b, path, info, Link|Symlink|Chown|Chmod, seen)
+	})
+	if err != nil {
+		tw.Close()
+		return err
+	}
+
+	err = filepath.Walk(a, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(a, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if _, err := os.Lstat(filepath.Join(b, rel)); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		dir, base := filepath.Split(rel)
+		hdr := &tar.Header{
+			Name:     filepath.ToSlash(filepath.Join(dir, whiteoutPrefix+base)),
+			Typeflag: tar.TypeReg,
+			Mode:     0600,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		tw.Close()
+		return err
+	}
+
+	return tw.Close()
+}
+
+// filesEqual reports whether the file at a and the file at b with
+// info are equal enough (by stat metadata, not content) to skip
+// emitting a diff entry for b. A missing a is never equal.
+func filesEqual(a, b string, info os.FileInfo) (bool, error) {
+	ai, err := os.Lstat(a)
+	if err != nil {
+		return false, err
+	}
+	return ai.Mode() == info.Mode() && ai.Size() == info.Size() && ai.ModTime().Equal(info.ModTime()), nil
+}
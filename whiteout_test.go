@@ -0,0 +1,50 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDiffTarStreamDedupsHardlinks verifies that two names for the
+// same inode under b come out of DiffTarStream as one TypeReg
+// entry followed by a TypeLink entry, not two full TypeReg copies.
+func TestDiffTarStreamDedupsHardlinks(t *testing.T) {
+	a := t.TempDir()
+	b := t.TempDir()
+
+	first := filepath.Join(b, "first")
+	if err := os.WriteFile(first, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(first, filepath.Join(b, "second")); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := DiffTarStream(context.Background(), a, b)
+	defer rc.Close()
+
+	var regCount, linkCount int
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			regCount++
+		case tar.TypeLink:
+			linkCount++
+		}
+	}
+	if regCount != 1 || linkCount != 1 {
+		t.Fatalf("got %d TypeReg and %d TypeLink entries, want 1 and 1 (hardlink dedup failed)", regCount, linkCount)
+	}
+}
@@ -0,0 +1,60 @@
+// +build linux
+
+package tarutil
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"strings"
+	"syscall"
+)
+
+const paxXattrPrefix = "SCHILY.xattr."
+
+// restoreXattrs sets the extended attributes recorded for hdr on
+// the file at path, used when the Xattrs flag is set. Attributes
+// are read from hdr.PAXRecords, falling back to the older
+// hdr.Xattrs for archives written by a pre-PAXRecords tar writer.
+func restoreXattrs(path string, hdr *tar.Header) error {
+	for key, val := range hdr.PAXRecords {
+		name := strings.TrimPrefix(key, paxXattrPrefix)
+		if name == key {
+			continue // not an xattr record
+		}
+		if err := setXattr(path, name, val); err != nil {
+			return err
+		}
+	}
+	for name, val := range hdr.Xattrs {
+		if _, ok := hdr.PAXRecords[paxXattrPrefix+name]; ok {
+			continue // already handled above
+		}
+		if err := setXattr(path, name, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setXattr(path, name, val string) error {
+	if name == "system.posix_acl_access" || name == "system.posix_acl_default" {
+		if !validPosixACL([]byte(val)) {
+			return nil // invalid/short buffer: ignore rather than fail the extract
+		}
+	}
+	return syscall.Setxattr(path, name, []byte(val), 0)
+}
+
+// validPosixACL reports whether data has the shape of the binary
+// POSIX ACL xattr format written by GNU tar: a 4-byte version
+// header (value 2) followed by zero or more 8-byte entries of
+// tag, perm and id. The value itself is passed through to
+// Setxattr unmodified, since that's the binary form the kernel
+// expects; this only guards against handing it a short or
+// malformed buffer.
+func validPosixACL(data []byte) bool {
+	if len(data) < 4 || (len(data)-4)%8 != 0 {
+		return false
+	}
+	return binary.LittleEndian.Uint32(data[:4]) == 0x0002
+}
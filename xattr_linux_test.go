@@ -0,0 +1,34 @@
+// +build linux
+
+package tarutil
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func aclBuf(entries int) []byte {
+	buf := make([]byte, 4+8*entries)
+	binary.LittleEndian.PutUint32(buf[:4], 0x0002)
+	return buf
+}
+
+func TestValidPosixACL(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"too short", []byte{0x02, 0x00}, false},
+		{"header only, no entries", aclBuf(0), true},
+		{"header plus one entry", aclBuf(1), true},
+		{"trailing partial entry", aclBuf(2)[:4+8+3], false},
+		{"wrong version", []byte{0x01, 0x00, 0x00, 0x00}, false},
+	}
+	for _, c := range cases {
+		if got := validPosixACL(c.data); got != c.want {
+			t.Errorf("%s: validPosixACL(%v) = %v, want %v", c.name, c.data, got, c.want)
+		}
+	}
+}